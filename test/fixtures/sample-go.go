@@ -3,20 +3,60 @@
 package sample
 
 import (
+	"bufio"
+	"bytes"
+	"container/list"
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // ErrNotFound is returned when an item is not found
 var ErrNotFound = errors.New("item not found")
 
+// ErrGone is returned when an item was deleted and only its tombstone remains
+var ErrGone = errors.New("item gone")
+
+// Backend identifies which Store implementation a Config should produce
+type Backend int
+
+const (
+	// BackendMemory selects MemoryStore
+	BackendMemory Backend = iota
+	// BackendSQLite selects SQLiteStore
+	BackendSQLite
+)
+
 // Config holds service configuration
 type Config struct {
 	MaxConnections int
 	Timeout        time.Duration
 	Debug          bool
+
+	// Backend selects the Store implementation produced by NewStore
+	Backend Backend
+	// SQLitePath is the database file used when Backend is BackendSQLite
+	SQLitePath string
+}
+
+// NewStore builds a Store according to cfg.Backend
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case BackendSQLite:
+		return NewSQLiteStore(cfg.SQLitePath)
+	default:
+		return NewMemoryStore(), nil
+	}
 }
 
 // Item represents a storable item
@@ -25,6 +65,11 @@ type Item struct {
 	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// Deleted marks this item as a tombstone rather than live data
+	Deleted bool
+	// DeletedAt records when the item was tombstoned
+	DeletedAt time.Time
 }
 
 // Store interface defines storage operations
@@ -32,22 +77,132 @@ type Store interface {
 	Get(ctx context.Context, id string) (*Item, error)
 	Put(ctx context.Context, item *Item) error
 	Delete(ctx context.Context, id string) error
+	Count() int
 }
 
 // MemoryStore implements Store with in-memory storage
 type MemoryStore struct {
 	mu    sync.RWMutex
 	items map[string]*Item
+
+	// refs and genItems back the generation-based refcounting used by
+	// NewGeneration/Generation.Release to collect stale items deterministically
+	nextGen  uint64
+	refs     map[string]int
+	genItems map[uint64]map[string]bool
 }
 
 // NewMemoryStore creates a new in-memory store
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		items: make(map[string]*Item),
+		items:    make(map[string]*Item),
+		refs:     make(map[string]int),
+		genItems: make(map[uint64]map[string]bool),
 	}
 }
 
-// Get retrieves an item by ID
+// Generation is a handle onto one scan's worth of item references. Items
+// touched through a Generation are refcounted; when the Generation is
+// released its references are dropped and any item whose refcount reaches
+// zero is tombstoned (see Release), to be physically removed by a later
+// Sweep. This lets a workspace scan
+// inherit extraction results unchanged across incremental re-scans while
+// guaranteeing stale entries are collected deterministically.
+type Generation struct {
+	store *MemoryStore
+	id    uint64
+}
+
+// NewGeneration opens a new generation against the store
+func (s *MemoryStore) NewGeneration() *Generation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextGen++
+	gen := s.nextGen
+	s.genItems[gen] = make(map[string]bool)
+	return &Generation{store: s, id: gen}
+}
+
+// ref increments the refcount for id within this generation, at most once
+// per generation
+func (g *Generation) ref(id string) {
+	touched := g.store.genItems[g.id]
+	if touched[id] {
+		return
+	}
+	touched[id] = true
+	g.store.refs[id]++
+}
+
+// Get retrieves an item by ID and marks it referenced by this generation. As
+// with MemoryStore.Get, it returns ErrGone for a tombstoned id rather than
+// silently handing back stale data, and does not ref a tombstone.
+func (g *Generation) Get(ctx context.Context, id string) (*Item, error) {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	item, ok := g.store.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if item.Deleted {
+		return nil, ErrGone
+	}
+	g.ref(id)
+	return item, nil
+}
+
+// Put stores an item and marks it referenced by this generation
+func (g *Generation) Put(ctx context.Context, item *Item) error {
+	if item == nil {
+		return errors.New("item cannot be nil")
+	}
+
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	now := time.Now()
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = now
+	}
+	item.UpdatedAt = now
+
+	g.store.items[item.ID] = item
+	g.ref(item.ID)
+	return nil
+}
+
+// Release decrements the refcount of every item this generation touched.
+// An item whose refcount drops to zero is tombstoned, not hard-deleted: it
+// goes through the same Delete/Sweep contract as MemoryStore.Delete, so a
+// sync layer still has a chance to observe it via Tombstones before Sweep
+// reclaims it. This keeps generation-based collection from racing ahead of
+// the tombstone propagation chunk0-2 relies on.
+func (g *Generation) Release() {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	for id := range g.store.genItems[g.id] {
+		g.store.refs[id]--
+		if g.store.refs[id] <= 0 {
+			delete(g.store.refs, id)
+			if item, ok := g.store.items[id]; ok && !item.Deleted {
+				// Same rationale as MemoryStore.Delete: replace with a
+				// tombstoned copy instead of mutating a pointer a caller
+				// may already be holding from a prior Get.
+				tombstone := *item
+				tombstone.Deleted = true
+				tombstone.DeletedAt = time.Now()
+				g.store.items[id] = &tombstone
+			}
+		}
+	}
+	delete(g.store.genItems, g.id)
+}
+
+// Get retrieves an item by ID. It returns ErrGone if id was deleted and its
+// tombstone has not yet been swept, and ErrNotFound if id never existed.
 func (s *MemoryStore) Get(ctx context.Context, id string) (*Item, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -56,6 +211,9 @@ func (s *MemoryStore) Get(ctx context.Context, id string) (*Item, error) {
 	if !ok {
 		return nil, ErrNotFound
 	}
+	if item.Deleted {
+		return nil, ErrGone
+	}
 	return item, nil
 }
 
@@ -78,23 +236,607 @@ func (s *MemoryStore) Put(ctx context.Context, item *Item) error {
 	return nil
 }
 
-// Delete removes an item by ID
+// Delete tombstones an item by ID rather than removing it outright, so that a
+// subsequent Get can distinguish "gone" from "never existed". Use Sweep to
+// reclaim tombstones once peers have had a chance to observe them.
 func (s *MemoryStore) Delete(ctx context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.items[id]; !ok {
+	item, ok := s.items[id]
+	if !ok || item.Deleted {
 		return ErrNotFound
 	}
-	delete(s.items, id)
+
+	// Replace the map entry with a tombstoned copy rather than mutating item
+	// in place: a caller may already be holding the pointer a prior Get
+	// returned, and writing through it would race with that read.
+	tombstone := *item
+	tombstone.Deleted = true
+	tombstone.DeletedAt = time.Now()
+	s.items[id] = &tombstone
 	return nil
 }
 
+// Sweep permanently removes tombstones older than olderThan
+func (s *MemoryStore) Sweep(olderThan time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-olderThan)
+	swept := 0
+	for id, item := range s.items {
+		if item.Deleted && item.DeletedAt.Before(cutoff) {
+			delete(s.items, id)
+			swept++
+		}
+	}
+	return swept
+}
+
+// Tombstones returns a channel yielding every currently tombstoned item, so a
+// sync layer can propagate deletions to peers
+func (s *MemoryStore) Tombstones() <-chan *Item {
+	out := make(chan *Item)
+
+	go func() {
+		defer close(out)
+
+		s.mu.RLock()
+		tombstones := make([]*Item, 0)
+		for _, item := range s.items {
+			if item.Deleted {
+				tombstones = append(tombstones, item)
+			}
+		}
+		s.mu.RUnlock()
+
+		for _, item := range tombstones {
+			out <- item
+		}
+	}()
+
+	return out
+}
+
 // Count returns the number of items in the store
 func (s *MemoryStore) Count() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.items)
+
+	count := 0
+	for _, item := range s.items {
+		if !item.Deleted {
+			count++
+		}
+	}
+	return count
+}
+
+// SQLiteStore implements Store with on-disk SQLite persistence
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path
+// and migrates it to the current schema
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite store: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate sqlite store: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrate creates the items table and its indexes if they don't already exist
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS items (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_items_id ON items(id);
+	`)
+	return err
+}
+
+// Get retrieves an item by ID
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*Item, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, name, created_at, updated_at FROM items WHERE id = ?`, id)
+
+	item := &Item{}
+	if err := row.Scan(&item.ID, &item.Name, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get item %s: %w", id, err)
+	}
+	return item, nil
+}
+
+// Put stores an item
+func (s *SQLiteStore) Put(ctx context.Context, item *Item) error {
+	if item == nil {
+		return errors.New("item cannot be nil")
+	}
+
+	now := time.Now()
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = now
+	}
+	item.UpdatedAt = now
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO items (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, updated_at = excluded.updated_at
+	`, item.ID, item.Name, item.CreatedAt, item.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("put item %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+// Delete removes an item by ID
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM items WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete item %s: %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete item %s: %w", id, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Count returns the number of items in the store
+func (s *SQLiteStore) Count() int {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&count); err != nil {
+		return 0
+	}
+	return count
+}
+
+// defaultInvalidationTopic is the Redis pub/sub channel used when
+// ShardedConfig.RedisTopic is left empty
+const defaultInvalidationTopic = "DELETE_CACHE_ITEM"
+
+// Defaults applied by NewShardedStore when ShardedConfig leaves the
+// corresponding TTL at its zero value, so a zero-value ShardedConfig still
+// caches rather than silently expiring every entry on arrival
+const (
+	defaultSuccessExpire = 5 * time.Minute
+	defaultFailedExpire  = 30 * time.Second
+	redisDialTimeout     = 5 * time.Second
+)
+
+// ShardedConfig configures a ShardedStore
+type ShardedConfig struct {
+	// SlotNum is the number of shards the keyspace is split across
+	SlotNum int
+	// SlotSize caps the number of entries held per shard; least recently used
+	// entries are evicted first once a shard is full
+	SlotSize int
+
+	// Backing is the authoritative Store the shards cache in front of. A
+	// cache miss falls through to Backing, and Put/Delete write through to
+	// it, so evicting or expiring a cache entry never loses data. If nil,
+	// ShardedStore behaves as a standalone cache with no persistence beyond
+	// its own LRU shards.
+	Backing Store
+
+	// RedisAddr, if set, enables cross-process cache invalidation: publishing
+	// an item ID on RedisTopic evicts it from every subscribed process
+	RedisAddr  string
+	RedisTopic string
+
+	// SuccessExpire and FailedExpire bound how long positive and negative
+	// (ErrNotFound) entries are trusted before Get re-fetches them
+	SuccessExpire time.Duration
+	FailedExpire  time.Duration
+}
+
+// cacheEntry is the value held by a shard's LRU list
+type cacheEntry struct {
+	key       string
+	item      *Item
+	err       error
+	expiresAt time.Time
+}
+
+// shard is a capped LRU cache guarded by its own mutex, so ShardedStore
+// spreads lock contention across SlotNum independent locks
+type shard struct {
+	mu    sync.Mutex
+	cap   int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+func newShard(cap int) *shard {
+	if cap <= 0 {
+		cap = 1
+	}
+	return &shard{
+		cap:   cap,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (sh *shard) get(key string) (*cacheEntry, bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	el, ok := sh.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		sh.ll.Remove(el)
+		delete(sh.items, key)
+		return nil, false
+	}
+	sh.ll.MoveToFront(el)
+	return entry, true
+}
+
+func (sh *shard) set(entry *cacheEntry) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if el, ok := sh.items[entry.key]; ok {
+		el.Value = entry
+		sh.ll.MoveToFront(el)
+		return
+	}
+
+	sh.items[entry.key] = sh.ll.PushFront(entry)
+	for sh.ll.Len() > sh.cap {
+		oldest := sh.ll.Back()
+		if oldest == nil {
+			break
+		}
+		sh.ll.Remove(oldest)
+		delete(sh.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (sh *shard) evict(key string) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if el, ok := sh.items[key]; ok {
+		sh.ll.Remove(el)
+		delete(sh.items, key)
+	}
+}
+
+// liveLen returns the number of entries caching a live item, excluding
+// negative ("not found") entries written by Delete
+func (sh *shard) liveLen() int {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	n := 0
+	for _, el := range sh.items {
+		if el.Value.(*cacheEntry).err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// redisSub holds the long-lived connection used to receive cache
+// invalidations published by peers
+type redisSub struct {
+	addr  string
+	topic string
+	conn  net.Conn
+	done  chan struct{}
+}
+
+// ShardedStore implements Store as a set of independently-locked LRU shards
+// in front of an authoritative Backing Store, so highly concurrent
+// ProcessItems workloads don't serialize on one mutex for reads that hit
+// the cache. An optional Redis subscriber evicts entries that peers report
+// deleted.
+type ShardedStore struct {
+	cfg   ShardedConfig
+	slots []*shard
+
+	// subMu guards sub, which is set asynchronously once connectAndSubscribe
+	// finishes dialing Redis in the background
+	subMu sync.Mutex
+	sub   *redisSub
+}
+
+// NewShardedStore builds a ShardedStore from cfg. If cfg.RedisAddr is set it
+// subscribes to cfg.RedisTopic and evicts any item ID published there; a
+// failure to connect just leaves invalidation disabled, since it's optional.
+func NewShardedStore(cfg ShardedConfig) *ShardedStore {
+	if cfg.SlotNum <= 0 {
+		cfg.SlotNum = 1
+	}
+	if cfg.RedisTopic == "" {
+		cfg.RedisTopic = defaultInvalidationTopic
+	}
+	if cfg.SuccessExpire <= 0 {
+		cfg.SuccessExpire = defaultSuccessExpire
+	}
+	if cfg.FailedExpire <= 0 {
+		cfg.FailedExpire = defaultFailedExpire
+	}
+
+	slots := make([]*shard, cfg.SlotNum)
+	for i := range slots {
+		slots[i] = newShard(cfg.SlotSize)
+	}
+
+	ss := &ShardedStore{cfg: cfg, slots: slots}
+
+	if cfg.RedisAddr != "" {
+		go ss.connectAndSubscribe()
+	}
+
+	return ss
+}
+
+// connectAndSubscribe dials cfg.RedisAddr and, on success, starts the
+// invalidation subscriber loop. It runs in its own goroutine so an
+// unreachable Redis host can't block NewShardedStore on the dial.
+func (s *ShardedStore) connectAndSubscribe() {
+	conn, err := net.DialTimeout("tcp", s.cfg.RedisAddr, redisDialTimeout)
+	if err != nil {
+		return
+	}
+
+	sub := &redisSub{addr: s.cfg.RedisAddr, topic: s.cfg.RedisTopic, conn: conn, done: make(chan struct{})}
+	s.subMu.Lock()
+	s.sub = sub
+	s.subMu.Unlock()
+
+	s.subscribeInvalidations(sub)
+}
+
+// Close stops the Redis subscriber goroutine and closes its connection. It
+// is a no-op if cfg.RedisAddr was never set.
+func (s *ShardedStore) Close() error {
+	sub := s.getSub()
+	if sub == nil {
+		return nil
+	}
+	err := sub.conn.Close()
+	<-sub.done
+	return err
+}
+
+// getSub returns the current subscriber connection, if any, guarding
+// against the race between connectAndSubscribe setting it and Close/Publish
+// reading it
+func (s *ShardedStore) getSub() *redisSub {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	return s.sub
+}
+
+// subscribeInvalidations listens on sub's topic and evicts locally any item
+// ID published by another process. It returns (and the goroutine exits)
+// once Close closes the connection out from under the blocking read.
+func (s *ShardedStore) subscribeInvalidations(sub *redisSub) {
+	defer close(sub.done)
+
+	r := bufio.NewReader(sub.conn)
+	if _, err := sub.conn.Write(respEncode("SUBSCRIBE", sub.topic)); err != nil {
+		return
+	}
+	if _, err := readRESPReply(r); err != nil {
+		return
+	}
+
+	for {
+		reply, err := readRESPReply(r)
+		if err != nil {
+			return
+		}
+		if len(reply) == 3 && reply[0] == "message" {
+			s.slotFor(reply[2]).evict(reply[2])
+		}
+	}
+}
+
+// Publish announces that id was deleted so every subscribed process evicts
+// it. It opens a short-lived connection per call since invalidations are
+// infrequent relative to Get/Put traffic.
+func (s *ShardedStore) Publish(ctx context.Context, id string) error {
+	sub := s.getSub()
+	if sub == nil {
+		return nil
+	}
+
+	conn, err := net.Dial("tcp", sub.addr)
+	if err != nil {
+		return fmt.Errorf("publish invalidation: %w", err)
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+
+	if _, err := conn.Write(respEncode("PUBLISH", sub.topic, id)); err != nil {
+		return fmt.Errorf("publish invalidation: %w", err)
+	}
+	if _, err := readRESPReply(bufio.NewReader(conn)); err != nil {
+		return fmt.Errorf("publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// respEncode renders parts as a RESP array of bulk strings, the wire format
+// Redis expects for a command
+func respEncode(parts ...string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(parts))
+	for _, p := range parts {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(p), p)
+	}
+	return buf.Bytes()
+}
+
+// readRESPReply reads one RESP value from r. Arrays are flattened into a
+// single slice of strings, which is all SUBSCRIBE/PUBLISH replies need.
+func readRESPReply(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return []string{line[1:]}, nil
+	case '-':
+		return nil, fmt.Errorf("redis error: %s", line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return []string{""}, nil
+		}
+		buf := make([]byte, n+2) // payload plus trailing \r\n
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return []string{string(buf[:n])}, nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			sub, err := readRESPReply(r)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, sub...)
+		}
+		return parts, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply: %q", line)
+	}
+}
+
+// slotFor returns the shard responsible for id
+func (s *ShardedStore) slotFor(id string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return s.slots[h.Sum32()%uint32(len(s.slots))]
+}
+
+// Get retrieves an item by ID, consulting the shard's LRU cache before
+// falling through to cfg.Backing (if any) on a miss
+func (s *ShardedStore) Get(ctx context.Context, id string) (*Item, error) {
+	sh := s.slotFor(id)
+
+	if entry, ok := sh.get(id); ok {
+		return entry.item, entry.err
+	}
+
+	if s.cfg.Backing == nil {
+		return nil, ErrNotFound
+	}
+
+	item, err := s.cfg.Backing.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			sh.set(&cacheEntry{key: id, err: ErrNotFound, expiresAt: time.Now().Add(s.cfg.FailedExpire)})
+		}
+		return nil, err
+	}
+
+	sh.set(&cacheEntry{key: id, item: item, expiresAt: time.Now().Add(s.cfg.SuccessExpire)})
+	return item, nil
+}
+
+// Put writes through to cfg.Backing (if any) before caching the item as a
+// positive entry for SuccessExpire
+func (s *ShardedStore) Put(ctx context.Context, item *Item) error {
+	if item == nil {
+		return errors.New("item cannot be nil")
+	}
+
+	if s.cfg.Backing != nil {
+		if err := s.cfg.Backing.Put(ctx, item); err != nil {
+			return err
+		}
+	} else {
+		now := time.Now()
+		if item.CreatedAt.IsZero() {
+			item.CreatedAt = now
+		}
+		item.UpdatedAt = now
+	}
+
+	s.slotFor(item.ID).set(&cacheEntry{
+		key:       item.ID,
+		item:      item,
+		expiresAt: time.Now().Add(s.cfg.SuccessExpire),
+	})
+	return nil
+}
+
+// Delete writes through to cfg.Backing (if any), caches the deletion as a
+// negative entry for FailedExpire, and publishes the eviction so peers
+// subscribed via Redis do the same
+func (s *ShardedStore) Delete(ctx context.Context, id string) error {
+	if s.cfg.Backing != nil {
+		if err := s.cfg.Backing.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	s.slotFor(id).set(&cacheEntry{
+		key:       id,
+		err:       ErrNotFound,
+		expiresAt: time.Now().Add(s.cfg.FailedExpire),
+	})
+	return s.Publish(ctx, id)
+}
+
+// Count reports the number of live items. With Backing set this delegates
+// to it, since Backing is authoritative; otherwise it counts only positive
+// (non-"not found") entries currently cached across all shards.
+func (s *ShardedStore) Count() int {
+	if s.cfg.Backing != nil {
+		return s.cfg.Backing.Count()
+	}
+
+	count := 0
+	for _, sh := range s.slots {
+		count += sh.liveLen()
+	}
+	return count
 }
 
 // helper is an unexported helper function
@@ -102,28 +844,86 @@ func helper(s string) string {
 	return s + "_processed"
 }
 
-// ProcessItems processes multiple items concurrently
-func ProcessItems(ctx context.Context, items []*Item, fn func(*Item) error) error {
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(items))
+// ProcessItems processes items concurrently through a pool of at most
+// maxWorkers goroutines (all of them if maxWorkers <= 0, e.g. when the
+// caller passes Config.MaxConnections). It honors ctx.Done() and stops
+// dispatching further work as soon as the context is canceled or fn
+// returns an error for any item. The returned error joins every per-item
+// failure (each wrapped in an *ItemError) together with ctx.Err(), if any.
+func ProcessItems(ctx context.Context, items []*Item, maxWorkers int, fn func(*Item) error) error {
+	if maxWorkers <= 0 {
+		maxWorkers = len(items)
+	}
+	if maxWorkers <= 0 {
+		return nil
+	}
+	if maxWorkers > len(items) {
+		maxWorkers = len(items)
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	for _, item := range items {
-		wg.Add(1)
-		go func(i *Item) {
+	work := make(chan *Item)
+	go func() {
+		defer close(work)
+		for _, item := range items {
+			select {
+			case work <- item:
+			case <-workCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	wg.Add(maxWorkers)
+	for w := 0; w < maxWorkers; w++ {
+		go func() {
 			defer wg.Done()
-			if err := fn(i); err != nil {
-				errCh <- err
+
+			for item := range work {
+				if workCtx.Err() != nil {
+					return
+				}
+
+				if err := fn(item); err != nil {
+					mu.Lock()
+					errs = append(errs, &ItemError{ID: item.ID, Err: err})
+					mu.Unlock()
+					cancel()
+				}
 			}
-		}(item)
+		}()
 	}
 
 	wg.Wait()
-	close(errCh)
 
-	for err := range errCh {
-		if err != nil {
-			return err
-		}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
 	}
-	return nil
+
+	return errors.Join(errs...)
+}
+
+// ItemError identifies which Item a ProcessItems failure came from. Use
+// errors.As to recover it from a joined error returned by ProcessItems.
+type ItemError struct {
+	ID  string
+	Err error
+}
+
+// Error implements the error interface
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %s: %v", e.ID, e.Err)
+}
+
+// Unwrap allows errors.Is/errors.As to see through to the underlying error
+func (e *ItemError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,323 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenerationSurvivesReReference(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	gen1 := store.NewGeneration()
+	if err := gen1.Put(ctx, &Item{ID: "a", Name: "first"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// The next scan opens its own generation and re-references "a" before
+	// the previous generation is released, as an incremental scan would.
+	gen2 := store.NewGeneration()
+	if _, err := gen2.Get(ctx, "a"); err != nil {
+		t.Fatalf("re-referencing item in the new generation: %v", err)
+	}
+
+	gen1.Release()
+
+	if _, err := store.Get(ctx, "a"); err != nil {
+		t.Fatalf("item still referenced by gen2 should be live, got: %v", err)
+	}
+
+	gen2.Release()
+}
+
+func TestGenerationDropsUnreferenced(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	gen1 := store.NewGeneration()
+	if err := gen1.Put(ctx, &Item{ID: "a", Name: "first"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// The next scan never touches "a" (e.g. the symbol disappeared).
+	gen2 := store.NewGeneration()
+	gen1.Release()
+
+	if _, err := store.Get(ctx, "a"); err != ErrGone {
+		t.Fatalf("item not referenced by gen2 should be tombstoned, got err=%v", err)
+	}
+
+	gen2.Release()
+
+	if n := store.Sweep(0); n != 1 {
+		t.Fatalf("expected Sweep to reclaim 1 tombstone, got %d", n)
+	}
+
+	if _, err := store.Get(ctx, "a"); err != ErrNotFound {
+		t.Fatalf("swept item should be gone entirely, got err=%v", err)
+	}
+}
+
+func TestGenerationConcurrentNoRace(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if err := store.Put(ctx, &Item{ID: "shared", Name: "v0"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	keeper := store.NewGeneration()
+	if _, err := keeper.Get(ctx, "shared"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	const concurrency = 50
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			gen := store.NewGeneration()
+			if _, err := gen.Get(ctx, "shared"); err != nil {
+				t.Errorf("Get: %v", err)
+			}
+			gen.Release()
+		}()
+	}
+	wg.Wait()
+
+	// keeper never released, so the item must still be live no matter how
+	// many short-lived generations raced through it
+	if _, err := store.Get(ctx, "shared"); err != nil {
+		t.Fatalf("item still referenced by keeper should be live, got: %v", err)
+	}
+
+	keeper.Release()
+
+	if _, err := store.Get(ctx, "shared"); err != ErrGone {
+		t.Fatalf("item with no remaining references should be tombstoned, got: %v", err)
+	}
+}
+
+func TestProcessItemsCancellationPropagation(t *testing.T) {
+	items := make([]*Item, 10)
+	for i := range items {
+		items[i] = &Item{ID: fmt.Sprintf("item-%d", i)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var processed int32
+
+	err := ProcessItems(ctx, items, 1, func(i *Item) error {
+		if atomic.AddInt32(&processed, 1) == 1 {
+			cancel()
+		}
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got: %v", err)
+	}
+	if n := atomic.LoadInt32(&processed); n != 1 {
+		t.Fatalf("expected exactly 1 item processed before cancellation stopped dispatch, got %d", n)
+	}
+}
+
+func TestProcessItemsWorkerPoolSaturation(t *testing.T) {
+	const maxWorkers = 3
+	items := make([]*Item, 20)
+	for i := range items {
+		items[i] = &Item{ID: fmt.Sprintf("item-%d", i)}
+	}
+
+	var current, peak int32
+	err := ProcessItems(context.Background(), items, maxWorkers, func(i *Item) error {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak > maxWorkers {
+		t.Fatalf("peak concurrency %d exceeded maxWorkers %d", peak, maxWorkers)
+	}
+	if peak < maxWorkers {
+		t.Fatalf("expected the pool to reach its cap of %d, peak was %d", maxWorkers, peak)
+	}
+}
+
+func TestProcessItemsMultiErrorAggregation(t *testing.T) {
+	items := []*Item{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	var startWG sync.WaitGroup
+	startWG.Add(len(items))
+	release := make(chan struct{})
+	go func() {
+		startWG.Wait()
+		close(release)
+	}()
+
+	err := ProcessItems(context.Background(), items, len(items), func(i *Item) error {
+		startWG.Done()
+		<-release // hold every worker open until all of them have started
+		return fmt.Errorf("boom: %s", i.ID)
+	})
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("expected errors.Join result, got: %v (%T)", err, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, sub := range joined.Unwrap() {
+		var itemErr *ItemError
+		if errors.As(sub, &itemErr) {
+			seen[itemErr.ID] = true
+		}
+	}
+	for _, item := range items {
+		if !seen[item.ID] {
+			t.Errorf("expected aggregated error to include failure for %s", item.ID)
+		}
+	}
+}
+
+// newTestStore builds a Store via the NewStore factory for the given
+// backend, so the round-trip test below exercises both through the same
+// entry point callers use.
+func newTestStore(t *testing.T, backend Backend) Store {
+	t.Helper()
+
+	cfg := Config{Backend: backend}
+	if backend == BackendSQLite {
+		cfg.SQLitePath = filepath.Join(t.TempDir(), "claudemem.db")
+	}
+
+	store, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore(%v): %v", backend, err)
+	}
+	return store
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	for _, backend := range []Backend{BackendMemory, BackendSQLite} {
+		backend := backend
+		t.Run(fmt.Sprintf("backend=%d", backend), func(t *testing.T) {
+			store := newTestStore(t, backend)
+			ctx := context.Background()
+
+			if _, err := store.Get(ctx, "missing"); err != ErrNotFound {
+				t.Fatalf("Get on empty store: want ErrNotFound, got %v", err)
+			}
+
+			item := &Item{ID: "sym-1", Name: "helper"}
+			if err := store.Put(ctx, item); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if item.CreatedAt.IsZero() || item.UpdatedAt.IsZero() {
+				t.Fatalf("Put should stamp CreatedAt/UpdatedAt, got %+v", item)
+			}
+
+			got, err := store.Get(ctx, "sym-1")
+			if err != nil {
+				t.Fatalf("Get after Put: %v", err)
+			}
+			if got.Name != "helper" {
+				t.Fatalf("Get returned Name=%q, want %q", got.Name, "helper")
+			}
+
+			if n := store.Count(); n != 1 {
+				t.Fatalf("Count after one Put: want 1, got %d", n)
+			}
+
+			// Put again with the same ID exercises the upsert path
+			// (insert-or-update for SQLiteStore, overwrite for MemoryStore).
+			if err := store.Put(ctx, &Item{ID: "sym-1", Name: "helper-renamed", CreatedAt: item.CreatedAt}); err != nil {
+				t.Fatalf("Put (update): %v", err)
+			}
+			if got, err := store.Get(ctx, "sym-1"); err != nil || got.Name != "helper-renamed" {
+				t.Fatalf("Get after update: got (%+v, %v), want Name=helper-renamed", got, err)
+			}
+			if n := store.Count(); n != 1 {
+				t.Fatalf("Count after update: want 1, got %d", n)
+			}
+
+			if err := store.Delete(ctx, "sym-1"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := store.Delete(ctx, "sym-1"); err != ErrNotFound && err != ErrGone {
+				t.Fatalf("Delete of already-deleted id: got %v, want ErrNotFound or ErrGone", err)
+			}
+		})
+	}
+}
+
+func drainTombstones(ch <-chan *Item) map[string]*Item {
+	out := make(map[string]*Item)
+	for item := range ch {
+		out[item.ID] = item
+	}
+	return out
+}
+
+func TestMemoryStoreTombstones(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if err := store.Put(ctx, &Item{ID: "a", Name: "keep"}); err != nil {
+		t.Fatalf("Put a: %v", err)
+	}
+	if err := store.Put(ctx, &Item{ID: "b", Name: "tombstoned"}); err != nil {
+		t.Fatalf("Put b: %v", err)
+	}
+	if err := store.Put(ctx, &Item{ID: "c", Name: "swept"}); err != nil {
+		t.Fatalf("Put c: %v", err)
+	}
+
+	if err := store.Delete(ctx, "b"); err != nil {
+		t.Fatalf("Delete b: %v", err)
+	}
+	if err := store.Delete(ctx, "c"); err != nil {
+		t.Fatalf("Delete c: %v", err)
+	}
+
+	tombstones := drainTombstones(store.Tombstones())
+	if _, ok := tombstones["a"]; ok {
+		t.Fatalf("live item %q should not appear in Tombstones()", "a")
+	}
+	if _, ok := tombstones["b"]; !ok {
+		t.Fatalf("tombstoned item %q should appear in Tombstones()", "b")
+	}
+	if _, ok := tombstones["c"]; !ok {
+		t.Fatalf("tombstoned item %q should appear in Tombstones()", "c")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if n := store.Sweep(2 * time.Millisecond); n != 2 {
+		t.Fatalf("expected Sweep to reclaim both aged tombstones, got %d", n)
+	}
+
+	tombstones = drainTombstones(store.Tombstones())
+	if _, ok := tombstones["b"]; ok {
+		t.Fatalf("swept item %q should no longer appear in Tombstones()", "b")
+	}
+	if _, ok := tombstones["c"]; ok {
+		t.Fatalf("swept item %q should no longer appear in Tombstones()", "c")
+	}
+}